@@ -4,6 +4,7 @@ package dirtree
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"path"
 	"sort"
@@ -63,7 +64,7 @@ func (dt DirTree) AddEntry(entry fs.DirEntry) {
 		panic("unknown entry type")
 	}
 	remoteParent := parentDir(entry.Remote())
-	dt.checkParent("", remoteParent, nil)
+	dt.checkParent("", remoteParent, nil, nil)
 }
 
 // Find returns the DirEntry for filePath or nil if not found
@@ -84,7 +85,10 @@ func (dt DirTree) Find(filePath string) (parentPath string, entry fs.DirEntry) {
 // If dirs is not nil it must contain entries for every *Dir found in
 // the tree. It is used to speed up the checking when calling this
 // repeatedly.
-func (dt DirTree) checkParent(root, dirPath string, dirs map[string]struct{}) {
+//
+// If modTimes is not nil then any directories created are given the
+// modification time found in it, otherwise the current time.
+func (dt DirTree) checkParent(root, dirPath string, dirs map[string]struct{}, modTimes map[string]time.Time) {
 	var parentPath string
 	for {
 		if dirPath == root {
@@ -104,7 +108,11 @@ func (dt DirTree) checkParent(root, dirPath string, dirs map[string]struct{}) {
 				return
 			}
 		}
-		dt[parentPath] = append(dt[parentPath], fs.NewDir(dirPath, time.Now()))
+		modTime := time.Now()
+		if modTimes != nil {
+			modTime = modTimes[dirPath]
+		}
+		dt[parentPath] = append(dt[parentPath], fs.NewDir(dirPath, modTime))
 		if dirs != nil {
 			dirs[dirPath] = struct{}{}
 		}
@@ -114,6 +122,43 @@ func (dt DirTree) checkParent(root, dirPath string, dirs map[string]struct{}) {
 
 // CheckParents checks every directory in the tree has *Dir in its parent
 func (dt DirTree) CheckParents(root string) {
+	dt.checkParents(root, nil)
+}
+
+// CheckParentsModTime checks every directory in the tree has *Dir in
+// its parent in the same way as CheckParents.
+//
+// Directories which have to be created are given the modification
+// time of their most recently modified descendant rather than the
+// current time. If none of the descendants has a modification time
+// then the directory's modification time is left unknown.
+//
+// This calls ModTime on every entry in the tree so should only be
+// used where that is cheap, for example on trees built in memory.
+func (dt DirTree) CheckParentsModTime(ctx context.Context, root string) {
+	modTimes := make(map[string]time.Time)
+	for _, entries := range dt {
+		for _, entry := range entries {
+			modTime := entry.ModTime(ctx)
+			// The modification time of a directory is always
+			// at least that of its children so we can stop as
+			// soon as we find a parent which is newer.
+			dirPath := entry.Remote()
+			for dirPath != "" {
+				dirPath = parentDir(dirPath)
+				if !modTime.After(modTimes[dirPath]) {
+					break
+				}
+				modTimes[dirPath] = modTime
+			}
+		}
+	}
+	dt.checkParents(root, modTimes)
+}
+
+// checkParents checks every directory in the tree has *Dir in its
+// parent using modTimes for the directories created as in checkParent
+func (dt DirTree) checkParents(root string, modTimes map[string]time.Time) {
 	dirs := make(map[string]struct{})
 	// Find all the directories and stick them in dirs
 	for _, entries := range dt {
@@ -124,7 +169,7 @@ func (dt DirTree) CheckParents(root string) {
 		}
 	}
 	for dirPath := range dt {
-		dt.checkParent(root, dirPath, dirs)
+		dt.checkParent(root, dirPath, dirs, modTimes)
 	}
 }
 
@@ -1,9 +1,12 @@
 package dirtree
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fstest/mockdir"
 	"github.com/rclone/rclone/fstest/mockobject"
 	"github.com/stretchr/testify/assert"
@@ -109,7 +112,7 @@ func TestDirTreeCheckParent(t *testing.T) {
   sausage
 `, dt.String())
 
-	dt.checkParent("", "dir/subdir", nil)
+	dt.checkParent("", "dir/subdir", nil, nil)
 
 	assert.Equal(t, `/
   dir/
@@ -142,6 +145,54 @@ dir/subdir2/
 `, dt.String())
 }
 
+func TestDirTreeCheckParentsModTime(t *testing.T) {
+	ctx := context.Background()
+	t1 := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t1.Add(-time.Hour)
+
+	newObject := func(remote string, modTime time.Time) fs.Object {
+		o := mockobject.New(remote).WithContent(nil, mockobject.SeekModeNone)
+		require.NoError(t, o.SetModTime(ctx, modTime))
+		return o
+	}
+
+	dt := New()
+	dt.Add(newObject("dir/subdir/sausage", t1))
+	dt.Add(newObject("dir/subdir2/sausage2", t2))
+	dt.AddDir(fs.NewDir("dir/subdir3", t3))
+
+	dt.CheckParentsModTime(ctx, "")
+	dt.Sort()
+
+	assert.Equal(t, `/
+  dir/
+dir/
+  subdir/
+  subdir2/
+  subdir3/
+dir/subdir/
+  sausage
+dir/subdir2/
+  sausage2
+dir/subdir3/
+`, dt.String())
+
+	for _, test := range []struct {
+		dirPath string
+		want    time.Time
+	}{
+		{"dir", t2},
+		{"dir/subdir", t1},
+		{"dir/subdir2", t2},
+		{"dir/subdir3", t3},
+	} {
+		_, entry := dt.Find(test.dirPath)
+		require.NotNil(t, entry, test.dirPath)
+		assert.Equal(t, test.want, entry.ModTime(ctx), test.dirPath)
+	}
+}
+
 func TestDirTreeSort(t *testing.T) {
 	dt := New()
 